@@ -0,0 +1,378 @@
+// Copyright (c) 2017, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package bibtex translates between BibTeX entries
+// and reclist records.
+//
+// A BibTeX entry such as
+//
+//	@article{smith2020,
+//		title  = {A study on records},
+//		author = {Smith, A. and Jones, B.},
+//	}
+//
+// is read as a reclist record with Type "article",
+// ID "smith2020",
+// and the entry fields as keys,
+// following reclist's own
+// lowercase, dash-normalized key convention.
+//
+// The reader understands brace-balanced values
+// ({... {...} ...}),
+// quoted values ("..."),
+// string concatenation with the sharp sign (#),
+// @string macro definitions,
+// and @preamble and @comment entries
+// (which are parsed but produce no record).
+package bibtex
+
+import (
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/js-arias/reclist"
+	"github.com/pkg/errors"
+)
+
+// A BibReader reads BibTeX entries
+// and translates them into reclist records.
+type BibReader struct {
+	closed bool
+	err    error
+	data   string
+	pos    int
+	macros map[string]string
+	rec    *reclist.Record
+}
+
+// NewBibReader returns a new BibReader
+// that reads from r.
+func NewBibReader(r io.Reader) *BibReader {
+	b := &BibReader{macros: make(map[string]string)}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		b.err = errors.Wrap(err, "bibtex: reader")
+		b.closed = true
+		return b
+	}
+	b.data = string(data)
+	return b
+}
+
+// Scan prepares the next record for reading
+// with the Record method.
+// It returns true on success,
+// or false if there is no next record
+// or an error happened while preparing it.
+// Err should be consulted to distinguish
+// between the two cases.
+//
+// Every call to Record,
+// must be preceded by a call to Scan.
+func (b *BibReader) Scan() bool {
+	if b.closed {
+		return false
+	}
+	for {
+		rec, err := b.nextEntry()
+		if err != nil {
+			b.closed = true
+			if errors.Cause(err) == io.EOF {
+				return false
+			}
+			b.err = err
+			return false
+		}
+		if rec == nil {
+			continue
+		}
+		b.rec = rec
+		return true
+	}
+}
+
+// Record returns the last read record.
+func (b *BibReader) Record() *reclist.Record {
+	if b.rec == nil {
+		panic("Record called without Scan")
+	}
+	rec := b.rec
+	b.rec = nil
+	return rec
+}
+
+// Err returns the error,
+// if any,
+// that was encountered during iteration.
+func (b *BibReader) Err() error {
+	return b.err
+}
+
+// nextEntry reads and parses the next BibTeX entry,
+// it returns a nil record (without error)
+// for entries that do not translate
+// into a reclist record,
+// such as @string, @preamble, and @comment.
+func (b *BibReader) nextEntry() (*reclist.Record, error) {
+	i := strings.IndexByte(b.data[b.pos:], '@')
+	if i < 0 {
+		b.pos = len(b.data)
+		return nil, io.EOF
+	}
+	b.pos += i + 1
+
+	typ, open := b.readIdent()
+	typ = strings.ToLower(strings.TrimSpace(typ))
+	if typ == "" {
+		return nil, errors.Errorf("bibtex: reader: malformed entry without type")
+	}
+
+	closeDelim := byte('}')
+	if open == '(' {
+		closeDelim = ')'
+	}
+	body, err := b.readBalanced(closeDelim)
+	if err != nil {
+		return nil, errors.Wrap(err, "bibtex: reader")
+	}
+
+	switch typ {
+	case "string":
+		key, val := b.splitKeyValue(body)
+		b.macros[strings.ToLower(strings.TrimSpace(key))] = b.resolveValue(val)
+		return nil, nil
+	case "preamble", "comment":
+		return nil, nil
+	}
+
+	id, rest := b.splitFirstComma(body)
+	rec := reclist.NewRecord(typ, strings.TrimSpace(id))
+	if rec == nil {
+		return nil, errors.Errorf("bibtex: reader: entry without a citation key")
+	}
+	for _, field := range b.splitFields(rest) {
+		if strings.TrimSpace(field) == "" {
+			continue
+		}
+		key, val := b.splitKeyValue(field)
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		rec.Set(key, b.resolveValue(val))
+	}
+	return rec, nil
+}
+
+// readIdent reads an identifier
+// up to the next opening brace or parenthesis,
+// it returns the identifier
+// and the opening delimiter found.
+func (b *BibReader) readIdent() (ident string, open byte) {
+	start := b.pos
+	for b.pos < len(b.data) {
+		c := b.data[b.pos]
+		if c == '{' || c == '(' {
+			break
+		}
+		b.pos++
+	}
+	ident = b.data[start:b.pos]
+	if b.pos < len(b.data) {
+		open = b.data[b.pos]
+		b.pos++ // skip the opening delimiter
+	}
+	return ident, open
+}
+
+// readBalanced reads the body of an entry,
+// up to the closing delimiter
+// that matches the opening brace or parenthesis
+// already consumed by readIdent,
+// keeping track of nested braces
+// so a brace used inside a value
+// does not end the entry early.
+func (b *BibReader) readBalanced(closeDelim byte) (string, error) {
+	start := b.pos
+	depth := 1
+	for b.pos < len(b.data) {
+		c := b.data[b.pos]
+		switch {
+		case c == '\\' && b.pos+1 < len(b.data):
+			b.pos++ // skip the escaped character
+		case c == '{' && closeDelim == '}':
+			depth++
+		case c == closeDelim:
+			depth--
+			if depth == 0 {
+				body := b.data[start:b.pos]
+				b.pos++
+				return body, nil
+			}
+		}
+		b.pos++
+	}
+	return "", io.EOF
+}
+
+// splitFirstComma splits s at the first comma
+// found outside braces and quotes,
+// it is used to separate the citation key
+// from the rest of the entry fields.
+func (b *BibReader) splitFirstComma(s string) (head, tail string) {
+	depth := 0
+	inQuote := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			i++
+		case c == '"' && depth == 0:
+			inQuote = !inQuote
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		case c == ',' && depth == 0 && !inQuote:
+			return s[:i], s[i+1:]
+		}
+	}
+	return s, ""
+}
+
+// splitFields splits s into its fields,
+// on commas found outside braces and quotes.
+func (b *BibReader) splitFields(s string) []string {
+	var fields []string
+	depth := 0
+	inQuote := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			i++
+		case c == '"' && depth == 0:
+			inQuote = !inQuote
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		case c == ',' && depth == 0 && !inQuote:
+			fields = append(fields, s[start:i])
+			start = i + 1
+		}
+	}
+	if strings.TrimSpace(s[start:]) != "" {
+		fields = append(fields, s[start:])
+	}
+	return fields
+}
+
+// splitKeyValue splits a field into its key and value,
+// on the first equal sign found outside braces and quotes.
+func (b *BibReader) splitKeyValue(s string) (key, value string) {
+	depth := 0
+	inQuote := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			i++
+		case c == '"' && depth == 0:
+			inQuote = !inQuote
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		case c == '=' && depth == 0 && !inQuote:
+			return s[:i], s[i+1:]
+		}
+	}
+	return s, ""
+}
+
+// resolveValue resolves a field value,
+// joining its tokens (separated by #),
+// stripping braces and quotes,
+// expanding @string macros,
+// and collapsing whitespace.
+func (b *BibReader) resolveValue(s string) string {
+	var out strings.Builder
+	for _, tok := range b.splitConcat(s) {
+		tok = strings.TrimSpace(tok)
+		if tok == "" {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(tok, "{") && strings.HasSuffix(tok, "}"):
+			out.WriteString(stripBraces(tok[1 : len(tok)-1]))
+		case strings.HasPrefix(tok, `"`) && strings.HasSuffix(tok, `"`):
+			out.WriteString(stripBraces(tok[1 : len(tok)-1]))
+		default:
+			if v, ok := b.macros[strings.ToLower(tok)]; ok {
+				out.WriteString(v)
+				continue
+			}
+			if _, err := strconv.ParseFloat(tok, 64); err == nil {
+				out.WriteString(tok)
+				continue
+			}
+			out.WriteString(tok)
+		}
+	}
+	return strings.Join(strings.Fields(out.String()), " ")
+}
+
+// splitConcat splits a value on the # string
+// concatenation operator,
+// found outside braces and quotes.
+func (b *BibReader) splitConcat(s string) []string {
+	var toks []string
+	depth := 0
+	inQuote := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			i++
+		case c == '"' && depth == 0:
+			inQuote = !inQuote
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		case c == '#' && depth == 0 && !inQuote:
+			toks = append(toks, s[start:i])
+			start = i + 1
+		}
+	}
+	toks = append(toks, s[start:])
+	return toks
+}
+
+// stripBraces removes case-protection braces
+// that may be nested inside a value,
+// such as "{DNA}" inside a title,
+// and unescapes the literal braces
+// written by escapeBraces (\{ and \}).
+func stripBraces(s string) string {
+	var out strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\\' && i+1 < len(s) && (s[i+1] == '{' || s[i+1] == '}') {
+			out.WriteByte(s[i+1])
+			i++
+			continue
+		}
+		if c == '{' || c == '}' {
+			continue
+		}
+		out.WriteByte(c)
+	}
+	return out.String()
+}
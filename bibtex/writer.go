@@ -0,0 +1,87 @@
+// Copyright (c) 2017, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package bibtex
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/js-arias/reclist"
+	"github.com/pkg/errors"
+)
+
+// A BibWriter writes reclist records
+// as BibTeX entries.
+type BibWriter struct {
+	w *bufio.Writer
+}
+
+// NewBibWriter returns a new BibWriter
+// that writes to w.
+func NewBibWriter(w io.Writer) *BibWriter {
+	return &BibWriter{w: bufio.NewWriter(w)}
+}
+
+// Write writes a single record
+// as a BibTeX entry.
+func (w *BibWriter) Write(rec *reclist.Record) error {
+	keys := rec.Keys()
+	if len(keys) == 0 {
+		return nil
+	}
+	if rec.ID() == "" || rec.Type() == "" {
+		return nil
+	}
+
+	if _, err := w.w.WriteString("@" + rec.Type() + "{" + rec.ID() + ",\n"); err != nil {
+		return errors.Wrap(err, "bibtex: writer")
+	}
+	for i, key := range keys {
+		value := rec.Get(key)
+		if value == "" {
+			continue
+		}
+		sep := ","
+		if i == len(keys)-1 {
+			sep = ""
+		}
+		s := "\t" + key + " = {" + escapeBraces(value) + "}" + sep + "\n"
+		if _, err := w.w.WriteString(s); err != nil {
+			return errors.Wrap(err, "bibtex: writer")
+		}
+	}
+	if _, err := w.w.WriteString("}\n"); err != nil {
+		return errors.Wrap(err, "bibtex: writer")
+	}
+	return nil
+}
+
+// escapeBraces escapes literal brace characters
+// in a value,
+// so it can be safely enclosed
+// in a BibTeX field.
+func escapeBraces(value string) string {
+	r := strings.NewReplacer("{", `\{`, "}", `\}`)
+	return r.Replace(value)
+}
+
+// Flush writes any buffered data
+// to the underlying io.Writer.
+// To check if an error occurred during the flush,
+// call Err.
+func (w *BibWriter) Flush() {
+	w.w.Flush()
+}
+
+// Err reports any error that has occurred
+// during a previous Write
+// or Flush.
+func (w *BibWriter) Err() error {
+	if _, err := w.w.Write(nil); err != nil {
+		return errors.Wrap(err, "bibtex: writer")
+	}
+	return nil
+}
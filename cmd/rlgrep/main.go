@@ -0,0 +1,57 @@
+// Copyright (c) 2017, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Rlgrep filters a reclist stream read from the standard input,
+// using the query language implemented
+// by the reclist/query package,
+// and writes the matching records,
+// in reclist format,
+// to the standard output.
+//
+// Usage:
+//
+//	rlgrep expr
+//
+// For example:
+//
+//	rlgrep '@type==planet;gravity=gt=1.0' < solar.rl > big.rl
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/js-arias/reclist"
+	"github.com/js-arias/reclist/query"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s expr\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	s, err := query.Filter(reclist.NewScanner(os.Stdin), os.Args[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+		os.Exit(1)
+	}
+
+	w := reclist.NewWriter(os.Stdout)
+	for s.Scan() {
+		if err := w.Write(s.Record()); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+			os.Exit(1)
+		}
+	}
+	if err := s.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+		os.Exit(1)
+	}
+	w.Flush()
+	if err := w.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[0], err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,360 @@
+// Copyright (c) 2017, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package reclist
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Options configures how a Namespace is built
+// from a reclist stream.
+type Options struct {
+	// ParentKey is the record key
+	// that holds a record's parent ID.
+	// If empty, "parent" is used.
+	ParentKey string
+}
+
+// A Namespace groups records into a hierarchy
+// of slash-separated paths,
+// derived from a parent reference
+// (by default, the "parent" key),
+// the plural of the record type,
+// and the lowercased record ID.
+//
+// For example, if moon "Ganymede" has parent "Jupiter",
+// and planet "Jupiter" has parent "Sun",
+// a star without a parent,
+// Ganymede is reached at the path "sun/planets/jupiter/moons/ganymede".
+type Namespace struct {
+	parentKey string
+	nodes     map[string]*nsNode
+	roots     []*nsNode
+}
+
+// nsNode is a single node of a Namespace tree.
+type nsNode struct {
+	rec      *Record
+	path     string
+	children []*nsNode
+}
+
+// A DanglingParentError reports records
+// whose parent ID does not match
+// any record in the namespace.
+type DanglingParentError struct {
+	// Dangling maps a record ID
+	// to its missing parent ID.
+	Dangling map[string]string
+}
+
+func (e *DanglingParentError) Error() string {
+	ids := make([]string, 0, len(e.Dangling))
+	for id := range e.Dangling {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var b strings.Builder
+	b.WriteString("reclist: namespace: dangling parents:")
+	for _, id := range ids {
+		b.WriteString(" " + id + "->" + e.Dangling[id])
+	}
+	return b.String()
+}
+
+// A CycleError reports a cycle found
+// while resolving a namespace hierarchy.
+type CycleError struct {
+	// Cycle lists the record IDs that form the cycle.
+	Cycle []string
+}
+
+func (e *CycleError) Error() string {
+	return "reclist: namespace: parent cycle: " + strings.Join(e.Cycle, " -> ")
+}
+
+// A DuplicateIDError reports record IDs
+// shared by more than one record type.
+//
+// A Namespace resolves parent references by ID alone
+// (a "parent" value has no type of its own),
+// so two records of different types
+// sharing an ID would otherwise overwrite
+// each other while the tree is being built,
+// silently corrupting it.
+type DuplicateIDError struct {
+	// Duplicate maps a record ID
+	// to the sorted list of types that share it.
+	Duplicate map[string][]string
+}
+
+func (e *DuplicateIDError) Error() string {
+	ids := make([]string, 0, len(e.Duplicate))
+	for id := range e.Duplicate {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var b strings.Builder
+	b.WriteString("reclist: namespace: duplicate IDs:")
+	for _, id := range ids {
+		b.WriteString(" " + id + "(" + strings.Join(e.Duplicate[id], ",") + ")")
+	}
+	return b.String()
+}
+
+// LoadNamespace reads every record from s,
+// and resolves it into a Namespace,
+// using a two-pass approach:
+// the first pass indexes every record by ID,
+// and the second pass links each record
+// to its parent,
+// computing its path.
+//
+// It returns a *DuplicateIDError
+// if two records of different types
+// share the same ID,
+// a *DanglingParentError
+// if a record's parent ID
+// does not match any record in s,
+// and a *CycleError
+// if the parent references form a cycle.
+func LoadNamespace(s *Scanner, opt Options) (*Namespace, error) {
+	parentKey := opt.ParentKey
+	if parentKey == "" {
+		parentKey = "parent"
+	}
+
+	var recs []*Record
+	byID := make(map[string]*Record)
+	typesByID := make(map[string]map[string]bool)
+	for s.Scan() {
+		rec := s.Record()
+		recs = append(recs, rec)
+		byID[rec.ID()] = rec
+		types, ok := typesByID[rec.ID()]
+		if !ok {
+			types = make(map[string]bool)
+			typesByID[rec.ID()] = types
+		}
+		types[rec.Type()] = true
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.Wrap(err, "reclist: namespace")
+	}
+
+	duplicate := make(map[string][]string)
+	for id, types := range typesByID {
+		if len(types) < 2 {
+			continue
+		}
+		list := make([]string, 0, len(types))
+		for typ := range types {
+			list = append(list, typ)
+		}
+		sort.Strings(list)
+		duplicate[id] = list
+	}
+	if len(duplicate) > 0 {
+		return nil, &DuplicateIDError{Duplicate: duplicate}
+	}
+
+	dangling := make(map[string]string)
+	for _, rec := range recs {
+		parent := rec.Get(parentKey)
+		if parent == "" {
+			continue
+		}
+		if _, ok := byID[parent]; !ok {
+			dangling[rec.ID()] = parent
+		}
+	}
+	if len(dangling) > 0 {
+		return nil, &DanglingParentError{Dangling: dangling}
+	}
+
+	ns := &Namespace{
+		parentKey: parentKey,
+		nodes:     make(map[string]*nsNode),
+	}
+
+	nodeOf := make(map[string]*nsNode, len(recs))
+	for _, rec := range recs {
+		nodeOf[rec.ID()] = &nsNode{rec: rec}
+	}
+
+	// detect cycles and compute each node's path,
+	// walking up the parent chain.
+	const (
+		white = 0
+		gray  = 1
+		black = 2
+	)
+	color := make(map[string]int, len(recs))
+	var path func(id string, stack []string) (string, error)
+	path = func(id string, stack []string) (string, error) {
+		n := nodeOf[id]
+		if n.path != "" {
+			return n.path, nil
+		}
+		if color[id] == gray {
+			// drop the records that only lead into the cycle.
+			start := 0
+			for i, sid := range stack {
+				if sid == id {
+					start = i
+					break
+				}
+			}
+			return "", &CycleError{Cycle: append(append([]string{}, stack[start:]...), id)}
+		}
+		color[id] = gray
+		stack = append(stack, id)
+
+		parent := n.rec.Get(parentKey)
+		var p string
+		if parent == "" {
+			p = strings.ToLower(id)
+		} else {
+			parentPath, err := path(parent, stack)
+			if err != nil {
+				return "", err
+			}
+			p = parentPath + "/" + pluralize(n.rec.Type()) + "/" + strings.ToLower(id)
+		}
+		n.path = p
+		color[id] = black
+		return p, nil
+	}
+
+	for _, rec := range recs {
+		if _, err := path(rec.ID(), nil); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, rec := range recs {
+		n := nodeOf[rec.ID()]
+		ns.nodes[n.path] = n
+		parent := rec.Get(parentKey)
+		if parent == "" {
+			ns.roots = append(ns.roots, n)
+			continue
+		}
+		pn := nodeOf[parent]
+		pn.children = append(pn.children, n)
+	}
+
+	sortNodes(ns.roots)
+	for _, n := range ns.nodes {
+		sortNodes(n.children)
+	}
+
+	return ns, nil
+}
+
+// sortNodes sorts a node slice by its record ID,
+// so tree traversal is deterministic.
+func sortNodes(nodes []*nsNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].rec.ID() < nodes[j].rec.ID()
+	})
+}
+
+// pluralize returns a naive English plural of a record type,
+// used as the path segment for a group of children,
+// e.g. "planet" becomes "planets".
+func pluralize(typ string) string {
+	if strings.HasSuffix(typ, "s") {
+		return typ
+	}
+	return typ + "s"
+}
+
+// Get returns the record at the given path,
+// or nil if there is no such path.
+func (ns *Namespace) Get(path string) *Record {
+	n, ok := ns.nodes[path]
+	if !ok {
+		return nil
+	}
+	return n.rec
+}
+
+// Children returns the paths of the direct children of path.
+// An empty path returns the paths of the root records.
+func (ns *Namespace) Children(path string) []string {
+	children := ns.childrenOf(path)
+	if children == nil {
+		return nil
+	}
+	paths := make([]string, len(children))
+	for i, n := range children {
+		paths[i] = n.path
+	}
+	return paths
+}
+
+// childrenOf returns the child nodes of path,
+// or the root nodes when path is empty.
+func (ns *Namespace) childrenOf(path string) []*nsNode {
+	if path == "" {
+		return ns.roots
+	}
+	n, ok := ns.nodes[path]
+	if !ok {
+		return nil
+	}
+	return n.children
+}
+
+// Walk calls fn for path and every one of its descendants,
+// in pre-order.
+// An empty path walks the whole namespace,
+// starting at its root records.
+// Walk stops, and returns the error,
+// at the first call to fn that returns an error.
+func (ns *Namespace) Walk(path string, fn func(path string, rec *Record) error) error {
+	if path == "" {
+		for _, n := range ns.roots {
+			if err := ns.walk(n, fn); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	n, ok := ns.nodes[path]
+	if !ok {
+		return nil
+	}
+	return ns.walk(n, fn)
+}
+
+// walk visits n and its descendants, in pre-order.
+func (ns *Namespace) walk(n *nsNode, fn func(path string, rec *Record) error) error {
+	if err := fn(n.path, n.rec); err != nil {
+		return err
+	}
+	for _, c := range n.children {
+		if err := ns.walk(c, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteNamespace writes every record of ns to w,
+// in topological order
+// (a parent is always written before its children),
+// so that re-reading the output
+// and calling LoadNamespace
+// produces the same tree.
+func WriteNamespace(w *Writer, ns *Namespace) error {
+	return ns.Walk("", func(path string, rec *Record) error {
+		return w.Write(rec)
+	})
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2017, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package reclist
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var nsBlob = `
+@star=Sun
+	radius:	109.3
+
+@planet=Jupiter
+	radius:	10.97
+	parent:	Sun
+
+@moon=Ganymede
+	radius:	0.4135
+	parent:	Jupiter
+
+@moon=Europa
+	radius:	0.145
+	parent:	Jupiter
+`
+
+func TestLoadNamespace(t *testing.T) {
+	ns, err := LoadNamespace(NewScanner(strings.NewReader(nsBlob)), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if rec := ns.Get("sun"); rec == nil || rec.ID() != "Sun" {
+		t.Fatalf("sun: got %v", rec)
+	}
+	if rec := ns.Get("sun/planets/jupiter"); rec == nil || rec.ID() != "Jupiter" {
+		t.Fatalf("sun/planets/jupiter: got %v", rec)
+	}
+	if rec := ns.Get("sun/planets/jupiter/moons/ganymede"); rec == nil || rec.ID() != "Ganymede" {
+		t.Fatalf("sun/planets/jupiter/moons/ganymede: got %v", rec)
+	}
+
+	roots := ns.Children("")
+	if len(roots) != 1 || roots[0] != "sun" {
+		t.Fatalf("roots = %v, want [sun]", roots)
+	}
+
+	moons := ns.Children("sun/planets/jupiter")
+	want := []string{"sun/planets/jupiter/moons/europa", "sun/planets/jupiter/moons/ganymede"}
+	if len(moons) != len(want) {
+		t.Fatalf("%d children, want %d", len(moons), len(want))
+	}
+	for i, p := range moons {
+		if p != want[i] {
+			t.Errorf("child %d = %q, want %q", i, p, want[i])
+		}
+	}
+
+	var visited []string
+	if err := ns.Walk("", func(path string, rec *Record) error {
+		visited = append(visited, path)
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantVisit := []string{"sun", "sun/planets/jupiter", "sun/planets/jupiter/moons/europa", "sun/planets/jupiter/moons/ganymede"}
+	if len(visited) != len(wantVisit) {
+		t.Fatalf("%d visited, want %d: %v", len(visited), len(wantVisit), visited)
+	}
+	for i, p := range visited {
+		if p != wantVisit[i] {
+			t.Errorf("visit %d = %q, want %q", i, p, wantVisit[i])
+		}
+	}
+}
+
+func TestWriteNamespace(t *testing.T) {
+	ns, err := LoadNamespace(NewScanner(strings.NewReader(nsBlob)), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := &bytes.Buffer{}
+	w := NewWriter(out)
+	if err := WriteNamespace(w, ns); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w.Flush()
+
+	ns2, err := LoadNamespace(NewScanner(strings.NewReader(out.String())), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error re-reading: %v", err)
+	}
+	if rec := ns2.Get("sun/planets/jupiter/moons/ganymede"); rec == nil {
+		t.Fatalf("sun/planets/jupiter/moons/ganymede missing after re-read")
+	}
+}
+
+func TestLoadNamespaceDanglingParent(t *testing.T) {
+	blob := `
+@moon=Titan
+	parent: Saturn
+`
+	_, err := LoadNamespace(NewScanner(strings.NewReader(blob)), Options{})
+	if err == nil {
+		t.Fatalf("expected a dangling parent error")
+	}
+	if _, ok := err.(*DanglingParentError); !ok {
+		t.Errorf("got %T, want *DanglingParentError", err)
+	}
+}
+
+func TestLoadNamespaceDuplicateID(t *testing.T) {
+	blob := `
+@moon=Io
+	parent: Jupiter
+
+@planet=Io
+	radius: 10.97
+`
+	_, err := LoadNamespace(NewScanner(strings.NewReader(blob)), Options{})
+	if err == nil {
+		t.Fatalf("expected a duplicate ID error")
+	}
+	if _, ok := err.(*DuplicateIDError); !ok {
+		t.Errorf("got %T, want *DuplicateIDError", err)
+	}
+}
+
+func TestLoadNamespaceCycle(t *testing.T) {
+	blob := `
+@moon=A
+	parent: B
+
+@moon=B
+	parent: A
+`
+	_, err := LoadNamespace(NewScanner(strings.NewReader(blob)), Options{})
+	if err == nil {
+		t.Fatalf("expected a cycle error")
+	}
+	if _, ok := err.(*CycleError); !ok {
+		t.Errorf("got %T, want *CycleError", err)
+	}
+}
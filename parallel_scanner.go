@@ -0,0 +1,182 @@
+// Copyright (c) 2017, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package reclist
+
+import (
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// A ParallelScanner reads records from a large reclist file,
+// partitioning it into byte ranges
+// that are parsed concurrently.
+//
+// Because records are self-delimited
+// by a "@type=id" header
+// starting at the beginning of a line,
+// each range's start is adjusted forward
+// to the next such header,
+// so no record is ever split between workers,
+// and the last range is read up to size.
+//
+// A ParallelScanner trades memory for throughput:
+// every worker buffers up to 16 of its own records
+// until they can be emitted in the original order,
+// and stops parsing while its buffer is full,
+// so peak memory use grows with the number of workers,
+// not with the size of the file,
+// while a plain Scanner only ever holds
+// the record currently being read.
+// For small files,
+// a plain Scanner is the better choice.
+type ParallelScanner struct {
+	recs chan *Record
+	rec  *Record
+	err  error
+}
+
+// NewParallelScanner returns a ParallelScanner
+// that reads the first size bytes of r,
+// using workers goroutines.
+func NewParallelScanner(r io.ReaderAt, size int64, workers int) *ParallelScanner {
+	ps := &ParallelScanner{recs: make(chan *Record, workers*4)}
+	go ps.run(r, size, workers)
+	return ps
+}
+
+// Records returns the channel on which
+// ps delivers records,
+// in the same order they appear in the source file.
+// The channel is closed once every range has been read,
+// or partitioning the input failed;
+// a read error ends only the range it happened in,
+// the records of the other ranges are still delivered.
+// Err should be consulted once the channel is drained.
+func (ps *ParallelScanner) Records() <-chan *Record {
+	return ps.recs
+}
+
+// Err returns the error,
+// if any,
+// that was found while parsing the file.
+// It must only be called after the Records channel is closed.
+func (ps *ParallelScanner) Err() error {
+	return ps.err
+}
+
+// run partitions [0, size) of r into workers byte ranges,
+// parses each range concurrently,
+// and feeds ps.recs in range order.
+func (ps *ParallelScanner) run(r io.ReaderAt, size int64, workers int) {
+	defer close(ps.recs)
+
+	if workers < 1 {
+		workers = 1
+	}
+	bounds, err := partitionBounds(r, size, workers)
+	if err != nil {
+		ps.err = err
+		return
+	}
+
+	var wg sync.WaitGroup
+	chans := make([]chan *Record, len(bounds)-1)
+	errs := make([]error, len(bounds)-1)
+	for i := range chans {
+		chans[i] = make(chan *Record, 16)
+		start, end := bounds[i], bounds[i+1]
+		if end <= start {
+			close(chans[i])
+			continue
+		}
+		wg.Add(1)
+		go func(i int, start, end int64) {
+			defer wg.Done()
+			defer close(chans[i])
+			sec := io.NewSectionReader(r, start, end-start)
+			s := NewScanner(sec)
+			for s.Scan() {
+				chans[i] <- s.Record()
+			}
+			errs[i] = s.Err()
+		}(i, start, end)
+	}
+
+	for i, ch := range chans {
+		for rec := range ch {
+			ps.recs <- rec
+		}
+		if errs[i] != nil && ps.err == nil {
+			ps.err = errs[i]
+		}
+	}
+	wg.Wait()
+}
+
+// partitionBounds splits [0, size) into workers ranges,
+// moving every internal boundary forward
+// to the start of the next record.
+func partitionBounds(r io.ReaderAt, size int64, workers int) ([]int64, error) {
+	bounds := make([]int64, workers+1)
+	bounds[workers] = size
+	for i := 1; i < workers; i++ {
+		raw := int64(i) * size / int64(workers)
+		adj, err := nextRecordStart(r, raw, size)
+		if err != nil {
+			return nil, err
+		}
+		bounds[i] = adj
+	}
+	for i := 1; i <= workers; i++ {
+		if bounds[i] < bounds[i-1] {
+			bounds[i] = bounds[i-1]
+		}
+	}
+	return bounds, nil
+}
+
+// nextRecordStart returns the offset of the next byte
+// at or after start
+// that begins a line starting with '@',
+// or size if there is none.
+func nextRecordStart(r io.ReaderAt, start, size int64) (int64, error) {
+	if start <= 0 {
+		return 0, nil
+	}
+	if start >= size {
+		return size, nil
+	}
+
+	var prev byte
+	one := make([]byte, 1)
+	if _, err := r.ReadAt(one, start-1); err != nil && err != io.EOF {
+		return 0, errors.Wrap(err, "reclist: parallel scanner")
+	}
+	prev = one[0]
+
+	const chunk = 4096
+	buf := make([]byte, chunk)
+	pos := start
+	for pos < size {
+		n, err := r.ReadAt(buf, pos)
+		if err != nil && err != io.EOF {
+			return 0, errors.Wrap(err, "reclist: parallel scanner")
+		}
+		for i := 0; i < n; i++ {
+			c := buf[i]
+			if c == '@' && prev == '\n' {
+				return pos + int64(i), nil
+			}
+			prev = c
+		}
+		pos += int64(n)
+		if n == 0 {
+			break
+		}
+	}
+	return size, nil
+}
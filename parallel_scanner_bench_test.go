@@ -0,0 +1,47 @@
+// Copyright (c) 2017, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package reclist
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// syntheticBlob repeats a small planet record
+// until it is at least n bytes long,
+// giving each repetition a unique ID
+// so records can be told apart.
+func syntheticBlob(n int) []byte {
+	var b bytes.Buffer
+	for i := 0; b.Len() < n; i++ {
+		b.WriteString(fmt.Sprintf("@planet=p%d\n\tradius: 1.0\n\tgravity: 1.0\n\n", i))
+	}
+	return b.Bytes()
+}
+
+func BenchmarkScanner(b *testing.B) {
+	data := string(syntheticBlob(4 << 20))
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s := NewScanner(strings.NewReader(data))
+		for s.Scan() {
+			s.Record()
+		}
+	}
+}
+
+func BenchmarkParallelScanner(b *testing.B) {
+	data := syntheticBlob(4 << 20)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ps := NewParallelScanner(bytes.NewReader(data), int64(len(data)), 4)
+		for range ps.Records() {
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2017, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package reclist
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParallelScanner(t *testing.T) {
+	data := []byte(blob)
+
+	s := NewScanner(strings.NewReader(blob))
+	var want []string
+	for s.Scan() {
+		want = append(want, s.Record().ID())
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ps := NewParallelScanner(bytes.NewReader(data), int64(len(data)), 4)
+	var got []string
+	for rec := range ps.Records() {
+		got = append(got, rec.ID())
+	}
+	if err := ps.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("%d records, want %d", len(got), len(want))
+	}
+	for i, id := range got {
+		if id != want[i] {
+			t.Errorf("record %d = %q, want %q", i, id, want[i])
+		}
+	}
+}
+
+func TestParallelScannerSingleWorker(t *testing.T) {
+	data := []byte(blob)
+	ps := NewParallelScanner(bytes.NewReader(data), int64(len(data)), 1)
+	n := 0
+	for range ps.Records() {
+		n++
+	}
+	if err := ps.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != len(testData) {
+		t.Errorf("%d records, want %d", n, len(testData))
+	}
+}
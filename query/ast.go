@@ -0,0 +1,177 @@
+// Copyright (c) 2017, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package query
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/js-arias/reclist"
+)
+
+// A Node is an element of a query syntax tree,
+// it evaluates a record against the query.
+type Node interface {
+	Eval(rec *reclist.Record) bool
+}
+
+// An AndNode is true when both of its operands are true.
+type AndNode struct {
+	Left, Right Node
+}
+
+// Eval implements the Node interface.
+func (n *AndNode) Eval(rec *reclist.Record) bool {
+	return n.Left.Eval(rec) && n.Right.Eval(rec)
+}
+
+// An OrNode is true when either of its operands is true.
+type OrNode struct {
+	Left, Right Node
+}
+
+// Eval implements the Node interface.
+func (n *OrNode) Eval(rec *reclist.Record) bool {
+	return n.Left.Eval(rec) || n.Right.Eval(rec)
+}
+
+// A NotNode negates its operand.
+type NotNode struct {
+	Node Node
+}
+
+// Eval implements the Node interface.
+func (n *NotNode) Eval(rec *reclist.Record) bool {
+	return !n.Node.Eval(rec)
+}
+
+// A CompareNode compares a record field
+// against one or more values.
+//
+// Values must hold at least one element
+// for the "==", "!=", "=gt=", "=ge=", "=lt=", "=le=", and "=re="
+// operators, which compare against Values[0];
+// Parse never builds a CompareNode that violates this,
+// but a CompareNode assembled by hand must respect it too:
+// Eval treats a missing value as a non-match
+// rather than panicking.
+type CompareNode struct {
+	Field  string
+	Op     string
+	Values []string
+}
+
+// Eval implements the Node interface.
+func (n *CompareNode) Eval(rec *reclist.Record) bool {
+	val := fieldValue(rec, n.Field)
+	switch n.Op {
+	case "==":
+		v, ok := n.first()
+		return ok && valuesEqual(val, v)
+	case "!=":
+		v, ok := n.first()
+		return ok && !valuesEqual(val, v)
+	case "=gt=", "=ge=", "=lt=", "=le=":
+		v, ok := n.first()
+		if !ok {
+			return false
+		}
+		a, b, ok := compareNumeric(val, v)
+		if !ok {
+			return false
+		}
+		switch n.Op {
+		case "=gt=":
+			return a > b
+		case "=ge=":
+			return a >= b
+		case "=lt=":
+			return a < b
+		default:
+			return a <= b
+		}
+	case "=in=":
+		return hasWord(val, n.Values)
+	case "=out=":
+		return !hasWord(val, n.Values)
+	case "=re=":
+		v, ok := n.first()
+		if !ok {
+			return false
+		}
+		re, err := regexp.Compile(v)
+		if err != nil {
+			return false
+		}
+		return re.MatchString(val)
+	case "=has=":
+		return hasWord(val, n.Values)
+	}
+	return false
+}
+
+// first returns n.Values[0] and true,
+// or "" and false if n.Values is empty.
+func (n *CompareNode) first() (string, bool) {
+	if len(n.Values) == 0 {
+		return "", false
+	}
+	return n.Values[0], true
+}
+
+// fieldValue returns the value of a field in rec,
+// resolving the @type and @id pseudo-fields.
+func fieldValue(rec *reclist.Record, field string) string {
+	switch field {
+	case "@type":
+		return rec.Type()
+	case "@id":
+		return rec.ID()
+	default:
+		return rec.Get(field)
+	}
+}
+
+// hasWord reports whether any of values
+// is a whitespace-separated word of val,
+// as used by =in=, =out=, and =has=.
+func hasWord(val string, values []string) bool {
+	words := strings.Fields(val)
+	for _, v := range values {
+		for _, w := range words {
+			if w == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// valuesEqual compares two values,
+// numerically if both parse as a float,
+// otherwise as strings.
+func valuesEqual(a, b string) bool {
+	if af, bf, ok := compareNumeric(a, b); ok {
+		return af == bf
+	}
+	return a == b
+}
+
+// compareNumeric parses a and b as floats,
+// it returns ok as false
+// if either value is not a number.
+func compareNumeric(a, b string) (af, bf float64, ok bool) {
+	var err error
+	af, err = strconv.ParseFloat(a, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	bf, err = strconv.ParseFloat(b, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return af, bf, true
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2017, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package query
+
+import (
+	"github.com/pkg/errors"
+)
+
+// A parser builds a query syntax tree
+// from an expression,
+// using a recursive-descent grammar:
+//
+//	expr   := and (('or') and)*
+//	and    := not (('and' | ';') not)*
+//	not    := 'not' not | atom
+//	atom   := '(' expr ')' | compare
+//	compare:= IDENT OP value
+//	value  := STRING | IDENT | '(' list ')'
+type parser struct {
+	tz  *tokenizer
+	cur token
+	err error
+}
+
+func newParser(s string) *parser {
+	p := &parser{tz: newTokenizer(s)}
+	p.advance()
+	return p
+}
+
+// advance reads the next token into p.cur.
+func (p *parser) advance() {
+	if p.err != nil {
+		return
+	}
+	tok, err := p.tz.next()
+	if err != nil {
+		p.err = err
+		return
+	}
+	p.cur = tok
+}
+
+// parse parses a full expression,
+// reporting an error if tokens remain
+// after a valid expression.
+func (p *parser) parse() (Node, error) {
+	n := p.parseOr()
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.cur.kind != tokEOF {
+		return nil, errors.Errorf("query: unexpected token after expression")
+	}
+	return n, nil
+}
+
+func (p *parser) parseOr() Node {
+	left := p.parseAnd()
+	for p.err == nil && p.cur.kind == tokOr {
+		p.advance()
+		right := p.parseAnd()
+		if p.err != nil {
+			return nil
+		}
+		left = &OrNode{Left: left, Right: right}
+	}
+	return left
+}
+
+func (p *parser) parseAnd() Node {
+	left := p.parseNot()
+	for p.err == nil && (p.cur.kind == tokAnd || p.cur.kind == tokSemi) {
+		p.advance()
+		right := p.parseNot()
+		if p.err != nil {
+			return nil
+		}
+		left = &AndNode{Left: left, Right: right}
+	}
+	return left
+}
+
+func (p *parser) parseNot() Node {
+	if p.cur.kind == tokNot {
+		p.advance()
+		n := p.parseNot()
+		if p.err != nil {
+			return nil
+		}
+		return &NotNode{Node: n}
+	}
+	return p.parseAtom()
+}
+
+func (p *parser) parseAtom() Node {
+	if p.err != nil {
+		return nil
+	}
+	if p.cur.kind == tokLParen {
+		p.advance()
+		n := p.parseOr()
+		if p.err != nil {
+			return nil
+		}
+		if p.cur.kind != tokRParen {
+			p.err = errors.Errorf("query: expected closing parenthesis")
+			return nil
+		}
+		p.advance()
+		return n
+	}
+	return p.parseCompare()
+}
+
+func (p *parser) parseCompare() Node {
+	if p.cur.kind != tokIdent {
+		p.err = errors.Errorf("query: expected a field name")
+		return nil
+	}
+	field := p.cur.val
+	p.advance()
+
+	if p.err != nil || p.cur.kind != tokOp {
+		p.err = errors.Errorf("query: expected a comparison operator after %q", field)
+		return nil
+	}
+	op := p.cur.val
+	p.advance()
+
+	values := p.parseValues()
+	if p.err != nil {
+		return nil
+	}
+	return &CompareNode{Field: field, Op: op, Values: values}
+}
+
+// parseValues parses the right-hand side of a comparison,
+// either a single value,
+// or a parenthesized, comma-separated list,
+// as used by the =in= and =out= operators.
+func (p *parser) parseValues() []string {
+	if p.cur.kind == tokLParen {
+		p.advance()
+		var values []string
+		for {
+			v := p.parseValue()
+			if p.err != nil {
+				return nil
+			}
+			values = append(values, v)
+			if p.cur.kind == tokComma {
+				p.advance()
+				continue
+			}
+			break
+		}
+		if p.cur.kind != tokRParen {
+			p.err = errors.Errorf("query: expected closing parenthesis in value list")
+			return nil
+		}
+		p.advance()
+		return values
+	}
+	v := p.parseValue()
+	if p.err != nil {
+		return nil
+	}
+	return []string{v}
+}
+
+// parseValue parses a single value token,
+// either a quoted string or a bareword.
+func (p *parser) parseValue() string {
+	switch p.cur.kind {
+	case tokString, tokIdent:
+		v := p.cur.val
+		p.advance()
+		return v
+	}
+	p.err = errors.Errorf("query: expected a value")
+	return ""
+}
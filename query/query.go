@@ -0,0 +1,96 @@
+// Copyright (c) 2017, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package query implements a small filter language
+// over reclist records.
+//
+// A query expression is built from comparisons
+// joined by the ';' (and), 'and', 'or', and 'not'
+// operators, and grouped with parentheses, for example:
+//
+//	@type==planet;gravity=gt=1.0;moons=in=(Titan,Moon)
+//
+// A comparison has the form field-op-value,
+// where field is either a record key,
+// or one of the pseudo-fields @type and @id,
+// that map to Record.Type and Record.ID.
+// The supported operators are
+// ==, != (equality),
+// =gt=, =ge=, =lt=, =le= (numeric comparison),
+// =in=, =out= (set membership, values in parentheses),
+// =re= (regular expression match),
+// and =has= (word is present in the value).
+// For == and !=,
+// when both sides parse as numbers,
+// they are compared numerically,
+// otherwise as strings;
+// =gt=, =ge=, =lt=, and =le= never match
+// when either side is not a number.
+package query
+
+import (
+	"github.com/js-arias/reclist"
+)
+
+// Parse parses a query expression
+// and returns the root of its syntax tree.
+func Parse(expr string) (Node, error) {
+	p := newParser(expr)
+	n, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// Filter returns a new scanner that skips over
+// the records of scanner
+// that do not match expr.
+func Filter(scanner *reclist.Scanner, expr string) (*Scanner, error) {
+	n, err := Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &Scanner{src: scanner, node: n}, nil
+}
+
+// A Scanner wraps a reclist.Scanner,
+// returning only the records
+// that match a query expression.
+type Scanner struct {
+	src  *reclist.Scanner
+	node Node
+	rec  *reclist.Record
+}
+
+// Scan advances the scanner to the next matching record.
+// It returns false when there are no more matching records,
+// or an error happened while reading the source scanner.
+func (s *Scanner) Scan() bool {
+	for s.src.Scan() {
+		rec := s.src.Record()
+		if s.node.Eval(rec) {
+			s.rec = rec
+			return true
+		}
+	}
+	return false
+}
+
+// Record returns the last matching record.
+func (s *Scanner) Record() *reclist.Record {
+	if s.rec == nil {
+		panic("Record called without Scan")
+	}
+	rec := s.rec
+	s.rec = nil
+	return rec
+}
+
+// Err returns the error,
+// if any,
+// that was encountered by the underlying scanner.
+func (s *Scanner) Err() error {
+	return s.src.Err()
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2017, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package query
+
+import (
+	"testing"
+
+	"github.com/js-arias/reclist"
+)
+
+func newTestRecord(typ, id string, fields map[string]string) *reclist.Record {
+	rec := reclist.NewRecord(typ, id)
+	for k, v := range fields {
+		rec.Set(k, v)
+	}
+	return rec
+}
+
+var jupiter = newTestRecord("planet", "Jupiter", map[string]string{
+	"gravity": "2.528",
+	"moons":   "Ganymede Callisto Io Europa",
+})
+
+var titan = newTestRecord("moon", "Titan", map[string]string{
+	"gravity": "0.14",
+	"parent":  "Saturn",
+})
+
+func TestFilterEval(t *testing.T) {
+	tests := []struct {
+		expr string
+		rec  *reclist.Record
+		want bool
+	}{
+		{`@type==planet`, jupiter, true},
+		{`@type==moon`, jupiter, false},
+		{`@id==Titan`, titan, true},
+		{`gravity=gt=1.0`, jupiter, true},
+		{`gravity=gt=1.0`, titan, false},
+		{`gravity=le=0.14`, titan, true},
+		{`moons=in=(Titan,Europa)`, jupiter, true},
+		{`moons=in=(Titan,Moon)`, jupiter, false},
+		{`moons=out=(Titan,Moon)`, jupiter, true},
+		{`moons=out=(Titan,Europa)`, jupiter, false},
+		{`moons=has=Io`, jupiter, true},
+		{`parent=re=^Sat`, titan, true},
+		{`parent=re=^Jup`, titan, false},
+		{`@type==planet;gravity=gt=1.0`, jupiter, true},
+		{`@type==planet;gravity=gt=3.0`, jupiter, false},
+		{`@type==planet or @type==moon`, titan, true},
+		{`not @type==planet`, titan, true},
+		{`not @type==planet`, jupiter, false},
+		{`@type==planet;(gravity=gt=3.0 or moons=has=Io)`, jupiter, true},
+	}
+	for _, test := range tests {
+		n, err := Parse(test.expr)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.expr, err)
+		}
+		if got := n.Eval(test.rec); got != test.want {
+			t.Errorf("%q on %s: got %v, want %v", test.expr, test.rec.ID(), got, test.want)
+		}
+	}
+}
+
+// TestCompareNodeNoValues checks that a hand-built CompareNode
+// with no Values does not panic,
+// since Node is exported and Parse is not the only way to build one.
+func TestCompareNodeNoValues(t *testing.T) {
+	tests := []struct {
+		op   string
+		want bool
+	}{
+		{"==", false},
+		{"!=", false},
+		{"=gt=", false},
+		{"=ge=", false},
+		{"=lt=", false},
+		{"=le=", false},
+		{"=re=", false},
+		{"=in=", false},
+		// =out= reads as "not in the given set",
+		// so an empty set vacuously matches.
+		{"=out=", true},
+		{"=has=", false},
+	}
+	for _, test := range tests {
+		n := &CompareNode{Field: "gravity", Op: test.op}
+		if got := n.Eval(jupiter); got != test.want {
+			t.Errorf("%q with no values: got %v, want %v", test.op, got, test.want)
+		}
+	}
+}
+
+func TestParseError(t *testing.T) {
+	tests := []string{
+		`@type==`,
+		`@type=planet`,
+		`(@type==planet`,
+		`@type==planet)`,
+	}
+	for _, expr := range tests {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("%q: expected an error", expr)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2017, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package query
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokIdent
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+	tokSemi
+	tokAnd
+	tokOr
+	tokNot
+)
+
+type token struct {
+	kind tokKind
+	val  string
+}
+
+// ops holds the comparison operators,
+// ordered so that longer operators
+// are matched before their prefixes.
+var ops = []string{
+	"=gt=", "=ge=", "=lt=", "=le=",
+	"=in=", "=out=", "=re=", "=has=",
+	"==", "!=",
+}
+
+// A tokenizer splits a query expression into tokens.
+type tokenizer struct {
+	s   string
+	pos int
+}
+
+func newTokenizer(s string) *tokenizer {
+	return &tokenizer{s: s}
+}
+
+// next returns the next token in the expression.
+func (t *tokenizer) next() (token, error) {
+	t.skipSpace()
+	if t.pos >= len(t.s) {
+		return token{kind: tokEOF}, nil
+	}
+
+	c := t.s[t.pos]
+	switch c {
+	case '(':
+		t.pos++
+		return token{kind: tokLParen}, nil
+	case ')':
+		t.pos++
+		return token{kind: tokRParen}, nil
+	case ',':
+		t.pos++
+		return token{kind: tokComma}, nil
+	case ';':
+		t.pos++
+		return token{kind: tokSemi}, nil
+	case '"':
+		return t.quoted()
+	}
+
+	if op, ok := t.matchOp(); ok {
+		return token{kind: tokOp, val: op}, nil
+	}
+
+	return t.word()
+}
+
+// skipSpace advances past any whitespace.
+func (t *tokenizer) skipSpace() {
+	for t.pos < len(t.s) && unicode.IsSpace(rune(t.s[t.pos])) {
+		t.pos++
+	}
+}
+
+// matchOp matches the longest operator
+// starting at the current position.
+func (t *tokenizer) matchOp() (string, bool) {
+	for _, op := range ops {
+		if strings.HasPrefix(t.s[t.pos:], op) {
+			t.pos += len(op)
+			return op, true
+		}
+	}
+	return "", false
+}
+
+// quoted reads a quoted string literal,
+// honoring backslash escapes,
+// following reclist's own quoting rules.
+func (t *tokenizer) quoted() (token, error) {
+	t.pos++ // skip the opening quote
+	var b strings.Builder
+	for t.pos < len(t.s) {
+		c := t.s[t.pos]
+		if c == '"' {
+			t.pos++
+			return token{kind: tokString, val: b.String()}, nil
+		}
+		if c == '\\' && t.pos+1 < len(t.s) {
+			t.pos++
+			c = t.s[t.pos]
+		}
+		b.WriteByte(c)
+		t.pos++
+	}
+	return token{}, errors.Errorf("query: unterminated string at position %d", t.pos)
+}
+
+// word reads a bareword:
+// an identifier, a keyword, or an unquoted value.
+func (t *tokenizer) word() (token, error) {
+	start := t.pos
+	for t.pos < len(t.s) {
+		c := t.s[t.pos]
+		if unicode.IsSpace(rune(c)) || strings.ContainsRune("(),;\"", rune(c)) {
+			break
+		}
+		if c == '=' || c == '!' {
+			if _, ok := t.matchOpAt(t.pos); ok {
+				break
+			}
+		}
+		t.pos++
+	}
+	if t.pos == start {
+		return token{}, errors.Errorf("query: unexpected character %q at position %d", t.s[t.pos], t.pos)
+	}
+	w := t.s[start:t.pos]
+	switch strings.ToLower(w) {
+	case "and":
+		return token{kind: tokAnd}, nil
+	case "or":
+		return token{kind: tokOr}, nil
+	case "not":
+		return token{kind: tokNot}, nil
+	}
+	return token{kind: tokIdent, val: w}, nil
+}
+
+// matchOpAt reports whether an operator
+// starts at position pos,
+// without advancing the tokenizer.
+func (t *tokenizer) matchOpAt(pos int) (string, bool) {
+	for _, op := range ops {
+		if strings.HasPrefix(t.s[pos:], op) {
+			return op, true
+		}
+	}
+	return "", false
+}
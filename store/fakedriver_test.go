@@ -0,0 +1,245 @@
+// Copyright (c) 2017, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package store
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// fakeDriver is a minimal, in-process database/sql driver
+// used to exercise SQLStore's generated SQL
+// (placeholder substitution, transactional Put,
+// and id-grouped Iterate)
+// without depending on a real SQLite or Postgres driver.
+//
+// It keeps a records table per DSN,
+// shared by every connection opened with that DSN,
+// the same way a real driver
+// shares a database across its connection pool.
+type fakeDriver struct{}
+
+func init() {
+	sql.Register("reclistfake", fakeDriver{})
+}
+
+type fakeRow struct {
+	typ, id, key, value string
+}
+
+type fakeDB struct {
+	mu   sync.Mutex
+	rows []fakeRow
+}
+
+var (
+	fakeDBsMu sync.Mutex
+	fakeDBs   = make(map[string]*fakeDB)
+)
+
+func fakeDBFor(name string) *fakeDB {
+	fakeDBsMu.Lock()
+	defer fakeDBsMu.Unlock()
+	db, ok := fakeDBs[name]
+	if !ok {
+		db = &fakeDB{}
+		fakeDBs[name] = db
+	}
+	return db
+}
+
+// Open implements driver.Driver.
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{db: fakeDBFor(name)}, nil
+}
+
+type fakeConn struct {
+	db       *fakeDB
+	snapshot []fakeRow
+	inTx     bool
+}
+
+// Prepare implements driver.Conn.
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+// Close implements driver.Conn.
+func (c *fakeConn) Close() error { return nil }
+
+// Begin implements driver.Conn.
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.db.mu.Lock()
+	c.snapshot = append([]fakeRow{}, c.db.rows...)
+	c.db.mu.Unlock()
+	c.inTx = true
+	return &fakeTx{conn: c}, nil
+}
+
+type fakeTx struct {
+	conn *fakeConn
+}
+
+// Commit implements driver.Tx.
+func (t *fakeTx) Commit() error {
+	t.conn.inTx = false
+	t.conn.snapshot = nil
+	return nil
+}
+
+// Rollback implements driver.Tx.
+func (t *fakeTx) Rollback() error {
+	if !t.conn.inTx {
+		return nil
+	}
+	t.conn.db.mu.Lock()
+	t.conn.db.rows = t.conn.snapshot
+	t.conn.db.mu.Unlock()
+	t.conn.inTx = false
+	t.conn.snapshot = nil
+	return nil
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+// Close implements driver.Stmt.
+func (s *fakeStmt) Close() error { return nil }
+
+// NumInput implements driver.Stmt,
+// -1 tells database/sql to skip argument-count checks,
+// since fakeStmt does not parse placeholders.
+func (s *fakeStmt) NumInput() int { return -1 }
+
+// Exec implements driver.Stmt.
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.conn.exec(s.query, args)
+}
+
+// Query implements driver.Stmt.
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.conn.query(s.query, args)
+}
+
+type fakeResult struct{}
+
+func (fakeResult) LastInsertId() (int64, error) { return 0, nil }
+func (fakeResult) RowsAffected() (int64, error) { return 0, nil }
+
+// exec recognizes the handful of statement shapes
+// SQLStore issues, regardless of which dialect's
+// placeholder syntax (? or $N) was used to build them.
+func (c *fakeConn) exec(query string, args []driver.Value) (driver.Result, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+
+	query = strings.TrimSpace(query)
+	switch {
+	case strings.HasPrefix(query, "CREATE TABLE"):
+		return fakeResult{}, nil
+	case strings.HasPrefix(query, "DELETE FROM records"):
+		typ, id := asString(args[0]), asString(args[1])
+		var kept []fakeRow
+		for _, r := range c.db.rows {
+			if r.typ == typ && r.id == id {
+				continue
+			}
+			kept = append(kept, r)
+		}
+		c.db.rows = kept
+		return fakeResult{}, nil
+	case strings.HasPrefix(query, "INSERT INTO records"):
+		c.db.rows = append(c.db.rows, fakeRow{
+			typ:   asString(args[0]),
+			id:    asString(args[1]),
+			key:   asString(args[2]),
+			value: asString(args[3]),
+		})
+		return fakeResult{}, nil
+	}
+	return nil, errors.New("fakedriver: unsupported exec query: " + query)
+}
+
+// query recognizes the handful of SELECT shapes SQLStore issues.
+func (c *fakeConn) query(query string, args []driver.Value) (driver.Rows, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(query, "SELECT key, value FROM records"):
+		typ, id := asString(args[0]), asString(args[1])
+		var data [][]driver.Value
+		for _, r := range c.db.rows {
+			if r.typ == typ && r.id == id {
+				data = append(data, []driver.Value{r.key, r.value})
+			}
+		}
+		return &fakeRows{cols: []string{"key", "value"}, data: data}, nil
+	case strings.HasPrefix(query, "SELECT id, key, value FROM records"):
+		typ := asString(args[0])
+		var matched []fakeRow
+		for _, r := range c.db.rows {
+			if r.typ == typ {
+				matched = append(matched, r)
+			}
+		}
+		sort.SliceStable(matched, func(i, j int) bool { return matched[i].id < matched[j].id })
+		data := make([][]driver.Value, len(matched))
+		for i, r := range matched {
+			data[i] = []driver.Value{r.id, r.key, r.value}
+		}
+		return &fakeRows{cols: []string{"id", "key", "value"}, data: data}, nil
+	case strings.HasPrefix(query, "SELECT DISTINCT type FROM records"):
+		seen := make(map[string]bool)
+		var types []string
+		for _, r := range c.db.rows {
+			if !seen[r.typ] {
+				seen[r.typ] = true
+				types = append(types, r.typ)
+			}
+		}
+		sort.Strings(types)
+		data := make([][]driver.Value, len(types))
+		for i, typ := range types {
+			data[i] = []driver.Value{typ}
+		}
+		return &fakeRows{cols: []string{"type"}, data: data}, nil
+	}
+	return nil, errors.New("fakedriver: unsupported query: " + query)
+}
+
+func asString(v driver.Value) string {
+	s, _ := v.(string)
+	return s
+}
+
+type fakeRows struct {
+	cols []string
+	data [][]driver.Value
+	pos  int
+}
+
+// Columns implements driver.Rows.
+func (r *fakeRows) Columns() []string { return r.cols }
+
+// Close implements driver.Rows.
+func (r *fakeRows) Close() error { return nil }
+
+// Next implements driver.Rows.
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.data) {
+		return io.EOF
+	}
+	copy(dest, r.data[r.pos])
+	r.pos++
+	return nil
+}
@@ -0,0 +1,20 @@
+// Copyright (c) 2017, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package store
+
+import _ "embed"
+
+// sqliteSchema and postgresSchema are the dialect-specific
+// "records" table migrations under migrations/,
+// embedded so NewSQLStore always creates the table
+// from the same files a human would run
+// against a real database,
+// instead of a hardcoded copy that could drift from them.
+
+//go:embed migrations/sqlite/0001_create_records.sql
+var sqliteSchema string
+
+//go:embed migrations/postgres/0001_create_records.sql
+var postgresSchema string
@@ -0,0 +1,206 @@
+// Copyright (c) 2017, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package store
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/js-arias/reclist"
+	"github.com/pkg/errors"
+)
+
+// A Dialect identifies the SQL dialect
+// used by a SQLStore,
+// it only affects placeholder syntax
+// and the DDL used for migration.
+type Dialect string
+
+// Supported dialects.
+const (
+	SQLite   Dialect = "sqlite3"
+	Postgres Dialect = "postgres"
+)
+
+// A SQLStore is a Store backed by a SQL database,
+// it keeps every key of a record
+// as a row of a single "records" table:
+//
+//	records(type TEXT, id TEXT, key TEXT, value TEXT, PRIMARY KEY(type, id, key))
+//
+// The caller is responsible for opening the *sql.DB
+// with the driver matching its Dialect
+// (e.g. "github.com/mattn/go-sqlite3" for SQLite,
+// or "github.com/lib/pq" for Postgres);
+// SQLStore only depends on database/sql.
+type SQLStore struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewSQLStore returns a SQLStore that uses db,
+// creating the records table
+// (from the migration matching dialect)
+// if it does not already exist.
+func NewSQLStore(db *sql.DB, dialect Dialect) (*SQLStore, error) {
+	var schema string
+	switch dialect {
+	case SQLite:
+		schema = sqliteSchema
+	case Postgres:
+		schema = postgresSchema
+	default:
+		return nil, errors.Errorf("store: unknown dialect %q", dialect)
+	}
+	st := &SQLStore{db: db, dialect: dialect}
+	if _, err := db.Exec(schema); err != nil {
+		return nil, errors.Wrap(err, "store: migrate")
+	}
+	return st, nil
+}
+
+// ph returns the i-th placeholder
+// in the store's dialect.
+func (st *SQLStore) ph(i int) string {
+	if st.dialect == Postgres {
+		return fmt.Sprintf("$%d", i)
+	}
+	return "?"
+}
+
+// Get implements the Store interface.
+func (st *SQLStore) Get(typ, id string) (*reclist.Record, error) {
+	q := fmt.Sprintf("SELECT key, value FROM records WHERE type = %s AND id = %s", st.ph(1), st.ph(2))
+	rows, err := st.db.Query(q, typ, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "store: get")
+	}
+	defer rows.Close()
+
+	var rec *reclist.Record
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, errors.Wrap(err, "store: get")
+		}
+		if rec == nil {
+			rec = reclist.NewRecord(typ, id)
+		}
+		rec.Set(key, value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "store: get")
+	}
+	return rec, nil
+}
+
+// Put implements the Store interface,
+// it keeps a whole record atomic
+// by replacing all of its keys
+// inside a single transaction.
+func (st *SQLStore) Put(rec *reclist.Record) error {
+	if rec == nil {
+		return errors.Errorf("store: record is nil")
+	}
+	if rec.Type() == "" || rec.ID() == "" {
+		return errors.Errorf("store: record without a type or ID")
+	}
+
+	tx, err := st.db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "store: put")
+	}
+	defer tx.Rollback()
+
+	del := fmt.Sprintf("DELETE FROM records WHERE type = %s AND id = %s", st.ph(1), st.ph(2))
+	if _, err := tx.Exec(del, rec.Type(), rec.ID()); err != nil {
+		return errors.Wrap(err, "store: put")
+	}
+
+	ins := fmt.Sprintf("INSERT INTO records (type, id, key, value) VALUES (%s, %s, %s, %s)",
+		st.ph(1), st.ph(2), st.ph(3), st.ph(4))
+	for _, key := range rec.Keys() {
+		if _, err := tx.Exec(ins, rec.Type(), rec.ID(), key, rec.Get(key)); err != nil {
+			return errors.Wrap(err, "store: put")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "store: put")
+	}
+	return nil
+}
+
+// Delete implements the Store interface.
+func (st *SQLStore) Delete(typ, id string) error {
+	q := fmt.Sprintf("DELETE FROM records WHERE type = %s AND id = %s", st.ph(1), st.ph(2))
+	if _, err := st.db.Exec(q, typ, id); err != nil {
+		return errors.Wrap(err, "store: delete")
+	}
+	return nil
+}
+
+// Iterate implements the Store interface,
+// records are emitted in ID order.
+func (st *SQLStore) Iterate(typ string, yield func(rec *reclist.Record) bool) error {
+	q := fmt.Sprintf("SELECT id, key, value FROM records WHERE type = %s ORDER BY id", st.ph(1))
+	rows, err := st.db.Query(q, typ)
+	if err != nil {
+		return errors.Wrap(err, "store: iterate")
+	}
+	defer rows.Close()
+
+	var cur *reclist.Record
+	flush := func() bool {
+		if cur == nil {
+			return true
+		}
+		ok := yield(cur)
+		cur = nil
+		return ok
+	}
+	for rows.Next() {
+		var id, key, value string
+		if err := rows.Scan(&id, &key, &value); err != nil {
+			return errors.Wrap(err, "store: iterate")
+		}
+		if cur != nil && cur.ID() != id {
+			if !flush() {
+				return nil
+			}
+		}
+		if cur == nil {
+			cur = reclist.NewRecord(typ, id)
+		}
+		cur.Set(key, value)
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "store: iterate")
+	}
+	flush()
+	return nil
+}
+
+// Types returns the distinct record types held by st.
+func (st *SQLStore) Types() ([]string, error) {
+	rows, err := st.db.Query("SELECT DISTINCT type FROM records")
+	if err != nil {
+		return nil, errors.Wrap(err, "store: types")
+	}
+	defer rows.Close()
+
+	var types []string
+	for rows.Next() {
+		var typ string
+		if err := rows.Scan(&typ); err != nil {
+			return nil, errors.Wrap(err, "store: types")
+		}
+		types = append(types, typ)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "store: types")
+	}
+	return types, nil
+}
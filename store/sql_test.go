@@ -0,0 +1,180 @@
+// Copyright (c) 2017, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package store
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/js-arias/reclist"
+)
+
+// openFakeSQLStore opens a SQLStore backed by a fresh,
+// process-local fake database,
+// so tests do not interfere with each other.
+func openFakeSQLStore(t *testing.T, dialect Dialect) *SQLStore {
+	t.Helper()
+	dsn := fmt.Sprintf("%s-%d", t.Name(), len(fakeDBs))
+	db, err := sql.Open("reclistfake", dsn)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	st, err := NewSQLStore(db, dialect)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return st
+}
+
+func TestSQLStoreGetPutDelete(t *testing.T) {
+	for _, dialect := range []Dialect{SQLite, Postgres} {
+		t.Run(string(dialect), func(t *testing.T) {
+			st := openFakeSQLStore(t, dialect)
+
+			jupiter := reclist.NewRecord("planet", "Jupiter")
+			jupiter.Set("gravity", "2.528")
+			jupiter.Set("radius", "10.97")
+			if err := st.Put(jupiter); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			rec, err := st.Get("planet", "Jupiter")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rec == nil {
+				t.Fatalf("Jupiter not found")
+			}
+			if rec.Get("gravity") != "2.528" {
+				t.Errorf("gravity = %q, want %q", rec.Get("gravity"), "2.528")
+			}
+			if rec.Get("radius") != "10.97" {
+				t.Errorf("radius = %q, want %q", rec.Get("radius"), "10.97")
+			}
+
+			if rec, err := st.Get("planet", "Pluto"); err != nil || rec != nil {
+				t.Errorf("Pluto: got %v, %v, want nil, nil", rec, err)
+			}
+
+			if err := st.Delete("planet", "Jupiter"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rec, err := st.Get("planet", "Jupiter"); err != nil || rec != nil {
+				t.Errorf("Jupiter after delete: got %v, %v, want nil, nil", rec, err)
+			}
+		})
+	}
+}
+
+// TestSQLStorePutReplacesKeys checks that Put replaces a whole record:
+// replacing a record that drops a key
+// must not leave the old key behind,
+// since Put deletes every stored key of the record
+// before it inserts the new ones.
+func TestSQLStorePutReplacesKeys(t *testing.T) {
+	st := openFakeSQLStore(t, SQLite)
+
+	titan := reclist.NewRecord("moon", "Titan")
+	titan.Set("parent", "Saturn")
+	titan.Set("note", "has a thick atmosphere")
+	if err := st.Put(titan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	titan2 := reclist.NewRecord("moon", "Titan")
+	titan2.Set("parent", "Saturn")
+	if err := st.Put(titan2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rec, err := st.Get("moon", "Titan")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.Get("note") != "" {
+		t.Errorf("note = %q, want it gone after replacing the record", rec.Get("note"))
+	}
+	if rec.Get("parent") != "Saturn" {
+		t.Errorf("parent = %q, want %q", rec.Get("parent"), "Saturn")
+	}
+}
+
+func TestSQLStoreIterateAndDumpTo(t *testing.T) {
+	st := openFakeSQLStore(t, Postgres)
+
+	jupiter := reclist.NewRecord("planet", "Jupiter")
+	jupiter.Set("gravity", "2.528")
+	mars := reclist.NewRecord("planet", "Mars")
+	mars.Set("gravity", "0.38")
+	titan := reclist.NewRecord("moon", "Titan")
+	titan.Set("parent", "Saturn")
+
+	for _, rec := range []*reclist.Record{jupiter, mars, titan} {
+		if err := st.Put(rec); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	var ids []string
+	if err := st.Iterate("planet", func(rec *reclist.Record) bool {
+		ids = append(ids, rec.ID())
+		return true
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != "Jupiter" || ids[1] != "Mars" {
+		t.Fatalf("planets = %v, want [Jupiter Mars]", ids)
+	}
+
+	out := &bytes.Buffer{}
+	if err := DumpTo(st, reclist.NewWriter(out)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	dumped := reclist.NewScanner(strings.NewReader(out.String()))
+	var dumpedIDs []string
+	for dumped.Scan() {
+		dumpedIDs = append(dumpedIDs, dumped.Record().ID())
+	}
+	if err := dumped.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(dumpedIDs) != 3 {
+		t.Fatalf("%d dumped records, want 3", len(dumpedIDs))
+	}
+}
+
+// TestSQLStoreFailedPutDoesNotChangeExisting proves that a rejected Put
+// never reaches the SQL transaction,
+// so a previously stored record is left untouched.
+func TestSQLStoreFailedPutDoesNotChangeExisting(t *testing.T) {
+	st := openFakeSQLStore(t, SQLite)
+
+	jupiter := reclist.NewRecord("planet", "Jupiter")
+	jupiter.Set("gravity", "2.528")
+	if err := st.Put(jupiter); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// reclist.NewRecord returns nil for an empty type or ID,
+	// and Put rejects a nil record before any SQL is issued,
+	// so the stored Jupiter record must be untouched.
+	if err := st.Put(reclist.NewRecord("", "")); err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	rec, err := st.Get("planet", "Jupiter")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec == nil || rec.Get("gravity") != "2.528" {
+		t.Errorf("Jupiter = %v, want gravity 2.528 intact", rec)
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2017, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package store defines a pluggable,
+// persistent storage interface
+// for reclist records,
+// plus an in-memory implementation
+// used for testing,
+// and a SQL-backed implementation
+// in the SQLStore type.
+//
+// A Store lets consumers use reclist
+// as an editable source-of-truth
+// while querying it through SQL,
+// a common pattern in flat-file
+// configuration systems.
+package store
+
+import (
+	"github.com/js-arias/reclist"
+	"github.com/pkg/errors"
+)
+
+// A Store persists reclist records,
+// indexed by their type and ID.
+type Store interface {
+	// Get returns the record with the given type and ID.
+	// It returns a nil record, without error,
+	// if there is no such record.
+	Get(typ, id string) (*reclist.Record, error)
+
+	// Put stores a record,
+	// replacing any previous record
+	// with the same type and ID.
+	// It returns an error if rec is nil
+	// or has no type or ID.
+	Put(rec *reclist.Record) error
+
+	// Delete removes the record
+	// with the given type and ID.
+	// It is a no-op if no such record exists.
+	Delete(typ, id string) error
+
+	// Iterate calls yield for every record of the given type,
+	// in no particular order,
+	// stopping early if yield returns false.
+	Iterate(typ string, yield func(rec *reclist.Record) bool) error
+
+	// Types returns the distinct record types currently held.
+	Types() ([]string, error)
+}
+
+// A MapStore is an in-memory Store,
+// mainly intended for testing.
+type MapStore struct {
+	data map[string]map[string]*reclist.Record
+}
+
+// NewMapStore returns a new, empty MapStore.
+func NewMapStore() *MapStore {
+	return &MapStore{data: make(map[string]map[string]*reclist.Record)}
+}
+
+// Get implements the Store interface.
+func (m *MapStore) Get(typ, id string) (*reclist.Record, error) {
+	return m.data[typ][id], nil
+}
+
+// Put implements the Store interface.
+func (m *MapStore) Put(rec *reclist.Record) error {
+	if rec == nil {
+		return errors.Errorf("store: record is nil")
+	}
+	if rec.Type() == "" || rec.ID() == "" {
+		return errors.Errorf("store: record without a type or ID")
+	}
+	recs, ok := m.data[rec.Type()]
+	if !ok {
+		recs = make(map[string]*reclist.Record)
+		m.data[rec.Type()] = recs
+	}
+	recs[rec.ID()] = rec
+	return nil
+}
+
+// Delete implements the Store interface.
+func (m *MapStore) Delete(typ, id string) error {
+	delete(m.data[typ], id)
+	return nil
+}
+
+// Iterate implements the Store interface.
+func (m *MapStore) Iterate(typ string, yield func(rec *reclist.Record) bool) error {
+	for _, rec := range m.data[typ] {
+		if !yield(rec) {
+			break
+		}
+	}
+	return nil
+}
+
+// Types implements the Store interface.
+func (m *MapStore) Types() ([]string, error) {
+	types := make([]string, 0, len(m.data))
+	for typ := range m.data {
+		types = append(types, typ)
+	}
+	return types, nil
+}
+
+// LoadFrom reads every record from s,
+// and stores it in st.
+func LoadFrom(st Store, s *reclist.Scanner) error {
+	for s.Scan() {
+		if err := st.Put(s.Record()); err != nil {
+			return errors.Wrap(err, "store: load")
+		}
+	}
+	if err := s.Err(); err != nil {
+		return errors.Wrap(err, "store: load")
+	}
+	return nil
+}
+
+// DumpTo writes every record held by st to w,
+// grouped by type.
+func DumpTo(st Store, w *reclist.Writer) error {
+	types, err := st.Types()
+	if err != nil {
+		return errors.Wrap(err, "store: dump")
+	}
+	for _, typ := range types {
+		var writeErr error
+		err := st.Iterate(typ, func(rec *reclist.Record) bool {
+			writeErr = w.Write(rec)
+			return writeErr == nil
+		})
+		if err != nil {
+			return errors.Wrap(err, "store: dump")
+		}
+		if writeErr != nil {
+			return errors.Wrap(writeErr, "store: dump")
+		}
+	}
+	w.Flush()
+	if err := w.Err(); err != nil {
+		return errors.Wrap(err, "store: dump")
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2017, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package store
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/js-arias/reclist"
+)
+
+var blob = `
+@planet=Jupiter
+	radius:	10.97
+	gravity: 2.528
+
+@planet=Mars
+	radius: 0.5320
+	gravity: 0.38
+
+@moon=Titan
+	radius:	0.4043
+	parent: Saturn
+`
+
+func TestMapStore(t *testing.T) {
+	st := NewMapStore()
+	s := reclist.NewScanner(strings.NewReader(blob))
+	if err := LoadFrom(st, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rec, err := st.Get("planet", "Jupiter")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec == nil {
+		t.Fatalf("Jupiter not found")
+	}
+	if rec.Get("gravity") != "2.528" {
+		t.Errorf("gravity = %q, want %q", rec.Get("gravity"), "2.528")
+	}
+
+	if rec, err := st.Get("planet", "Pluto"); err != nil || rec != nil {
+		t.Errorf("Pluto: got %v, %v, want nil, nil", rec, err)
+	}
+
+	var planets []string
+	if err := st.Iterate("planet", func(rec *reclist.Record) bool {
+		planets = append(planets, rec.ID())
+		return true
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(planets) != 2 {
+		t.Errorf("%d planets, want 2", len(planets))
+	}
+
+	if err := st.Delete("planet", "Mars"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec, err := st.Get("planet", "Mars"); err != nil || rec != nil {
+		t.Errorf("Mars: got %v, %v, want nil, nil", rec, err)
+	}
+}
@@ -0,0 +1,295 @@
+// Copyright (c) 2017, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package watch turns a reclist file
+// into a live-editable configuration source.
+//
+// A Watcher tails a reclist file,
+// re-parsing it on every modification,
+// and comparing the new record set
+// against the previous one,
+// by (type, ID),
+// to report what changed
+// through a channel of Events.
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/js-arias/reclist"
+	"github.com/pkg/errors"
+)
+
+// debounce is how long run waits after the last matching fsnotify event
+// before actually reloading the file.
+//
+// A writer that truncates a file before rewriting its content
+// (as os.WriteFile does) can trigger a Write event
+// while the file is momentarily empty;
+// reloading on that event would misreport every record as removed,
+// only to report it added again once the real write lands.
+// Waiting for the events to go quiet avoids reading that transient state.
+const debounce = 20 * time.Millisecond
+
+// A Kind identifies the nature of an Event.
+type Kind int
+
+// Event kinds.
+const (
+	Added Kind = iota
+	Modified
+	Removed
+)
+
+// A Delta holds the previous and current value of a key
+// that changed between two versions of a record.
+type Delta struct {
+	Old, New string
+}
+
+// An Event reports a single record change,
+// detected while re-reading a watched file.
+type Event struct {
+	Kind Kind
+
+	// Record is the current version of the record.
+	// It is nil for a Removed event.
+	Record *reclist.Record
+
+	// Prev is the previous version of the record.
+	// It is nil for an Added event.
+	Prev *reclist.Record
+
+	// Delta holds the keys that changed,
+	// it is only set for a Modified event.
+	Delta map[string]Delta
+}
+
+// A Watcher watches a reclist file for changes,
+// emitting an Event for every record
+// that is added, modified, or removed.
+type Watcher struct {
+	path string
+	fw   *fsnotify.Watcher
+	evC  chan Event
+	done chan struct{}
+
+	mu   sync.Mutex
+	snap map[string]map[string]*reclist.Record
+}
+
+// NewWatcher returns a new Watcher
+// that watches the reclist file at path.
+func NewWatcher(path string) (*Watcher, error) {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errors.Wrap(err, "watch: new watcher")
+	}
+	if err := fw.Add(filepath.Dir(path)); err != nil {
+		fw.Close()
+		return nil, errors.Wrap(err, "watch: new watcher")
+	}
+
+	w := &Watcher{
+		path: path,
+		fw:   fw,
+		evC:  make(chan Event),
+		done: make(chan struct{}),
+	}
+
+	snap, err := loadSnapshot(path)
+	if err != nil {
+		fw.Close()
+		return nil, err
+	}
+	w.snap = snap
+
+	go w.run()
+	return w, nil
+}
+
+// Events returns the channel on which
+// w reports record changes.
+func (w *Watcher) Events() <-chan Event {
+	return w.evC
+}
+
+// Close stops w from watching its file.
+func (w *Watcher) Close() error {
+	close(w.done)
+	return w.fw.Close()
+}
+
+// Snapshot returns a consistent view
+// of the records currently known to w,
+// indexed by type and then by ID.
+func (w *Watcher) Snapshot() map[string]map[string]*reclist.Record {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	snap := make(map[string]map[string]*reclist.Record, len(w.snap))
+	for typ, recs := range w.snap {
+		m := make(map[string]*reclist.Record, len(recs))
+		for id, rec := range recs {
+			m[id] = rec
+		}
+		snap[typ] = m
+	}
+	return snap
+}
+
+// run watches for file system events
+// on the watched file,
+// reloading and diffing its content
+// once events on it go quiet for debounce.
+func (w *Watcher) run() {
+	timer := time.NewTimer(debounce)
+	if !timer.Stop() {
+		<-timer.C
+	}
+	pending := false
+
+	for {
+		select {
+		case <-w.done:
+			timer.Stop()
+			return
+		case ev, ok := <-w.fw.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(ev.Name) != filepath.Clean(w.path) {
+				continue
+			}
+			if ev.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			if pending && !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(debounce)
+			pending = true
+		case <-timer.C:
+			pending = false
+			w.reload()
+		case _, ok := <-w.fw.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// reload re-reads the watched file,
+// emitting an Event for every record
+// that was added, modified, or removed
+// since the previous version.
+func (w *Watcher) reload() {
+	snap, err := loadSnapshot(w.path)
+	if err != nil {
+		return
+	}
+
+	w.mu.Lock()
+	events := diffSnapshot(w.snap, snap)
+	w.snap = snap
+	w.mu.Unlock()
+
+	for _, ev := range events {
+		select {
+		case w.evC <- ev:
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// loadSnapshot reads every record of the reclist file at path,
+// indexing it by type and then by ID.
+func loadSnapshot(path string) (map[string]map[string]*reclist.Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "watch: load")
+	}
+	defer f.Close()
+
+	snap := make(map[string]map[string]*reclist.Record)
+	s := reclist.NewScanner(f)
+	for s.Scan() {
+		rec := s.Record()
+		recs, ok := snap[rec.Type()]
+		if !ok {
+			recs = make(map[string]*reclist.Record)
+			snap[rec.Type()] = recs
+		}
+		recs[rec.ID()] = rec
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.Wrap(err, "watch: load")
+	}
+	return snap, nil
+}
+
+// diffSnapshot compares two snapshots of a reclist file,
+// returning an Event for every record
+// that was added, modified, or removed.
+func diffSnapshot(old, cur map[string]map[string]*reclist.Record) []Event {
+	var events []Event
+
+	for typ, recs := range cur {
+		oldRecs := old[typ]
+		for id, rec := range recs {
+			var prev *reclist.Record
+			if oldRecs != nil {
+				prev = oldRecs[id]
+			}
+			if prev == nil {
+				events = append(events, Event{Kind: Added, Record: rec})
+				continue
+			}
+			if delta := diffRecord(prev, rec); len(delta) > 0 {
+				events = append(events, Event{Kind: Modified, Record: rec, Prev: prev, Delta: delta})
+			}
+		}
+	}
+
+	for typ, recs := range old {
+		curRecs := cur[typ]
+		for id, rec := range recs {
+			if curRecs != nil {
+				if _, ok := curRecs[id]; ok {
+					continue
+				}
+			}
+			events = append(events, Event{Kind: Removed, Prev: rec})
+		}
+	}
+
+	return events
+}
+
+// diffRecord returns the keys that changed
+// between two versions of a record.
+func diffRecord(prev, cur *reclist.Record) map[string]Delta {
+	seen := make(map[string]bool)
+	for _, k := range prev.Keys() {
+		seen[k] = true
+	}
+	for _, k := range cur.Keys() {
+		seen[k] = true
+	}
+
+	delta := make(map[string]Delta)
+	for k := range seen {
+		o, n := prev.Get(k), cur.Get(k)
+		if o != n {
+			delta[k] = Delta{Old: o, New: n}
+		}
+	}
+	return delta
+}
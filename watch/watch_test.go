@@ -0,0 +1,207 @@
+// Copyright (c) 2017, J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/js-arias/reclist"
+)
+
+func newRec(typ, id string, fields map[string]string) *reclist.Record {
+	rec := reclist.NewRecord(typ, id)
+	for k, v := range fields {
+		rec.Set(k, v)
+	}
+	return rec
+}
+
+func snapOf(recs ...*reclist.Record) map[string]map[string]*reclist.Record {
+	snap := make(map[string]map[string]*reclist.Record)
+	for _, rec := range recs {
+		m, ok := snap[rec.Type()]
+		if !ok {
+			m = make(map[string]*reclist.Record)
+			snap[rec.Type()] = m
+		}
+		m[rec.ID()] = rec
+	}
+	return snap
+}
+
+func TestDiffSnapshot(t *testing.T) {
+	jupiter := newRec("planet", "Jupiter", map[string]string{"gravity": "2.528"})
+	jupiterV2 := newRec("planet", "Jupiter", map[string]string{"gravity": "2.6"})
+	mars := newRec("planet", "Mars", map[string]string{"gravity": "0.38"})
+
+	old := snapOf(jupiter, mars)
+	cur := snapOf(jupiterV2)
+
+	events := diffSnapshot(old, cur)
+	if len(events) != 2 {
+		t.Fatalf("%d events, want 2", len(events))
+	}
+
+	var mod, rem bool
+	for _, ev := range events {
+		switch ev.Kind {
+		case Modified:
+			mod = true
+			if ev.Record.ID() != "Jupiter" {
+				t.Errorf("modified record %q, want Jupiter", ev.Record.ID())
+			}
+			d, ok := ev.Delta["gravity"]
+			if !ok {
+				t.Fatalf("missing delta for gravity")
+			}
+			if d.Old != "2.528" || d.New != "2.6" {
+				t.Errorf("gravity delta = %+v, want {2.528 2.6}", d)
+			}
+		case Removed:
+			rem = true
+			if ev.Prev.ID() != "Mars" {
+				t.Errorf("removed record %q, want Mars", ev.Prev.ID())
+			}
+		case Added:
+			t.Errorf("unexpected added event for %s", ev.Record.ID())
+		}
+	}
+	if !mod || !rem {
+		t.Errorf("mod=%v rem=%v, want both true", mod, rem)
+	}
+}
+
+// waitForSnapshot polls w.Snapshot() until it equals want,
+// failing the test if that state is never reached before the timeout.
+//
+// fsnotify does not guarantee one event per write
+// (the OS can coalesce events for writes that land close together),
+// so tests must synchronize on the resulting state
+// rather than on a fixed number of Events.
+func waitForSnapshot(t *testing.T, w *Watcher, want map[string]map[string]*reclist.Record) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if got := w.Snapshot(); reflect.DeepEqual(got, want) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("snapshot = %+v, want %+v", w.Snapshot(), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "planets.rl")
+
+	if err := os.WriteFile(path, []byte("@planet=Jupiter\n\tgravity: 2.528\n"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	w, err := NewWatcher(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Close()
+
+	// Events is unbuffered, so it must be drained continuously
+	// or reload would block delivering them;
+	// the accumulated events are checked once the file
+	// has settled on each expected state.
+	var mu sync.Mutex
+	var events []Event
+	go func() {
+		for ev := range w.Events() {
+			mu.Lock()
+			events = append(events, ev)
+			mu.Unlock()
+		}
+	}()
+
+	// Added: a new record appears in the file.
+	if err := os.WriteFile(path, []byte("@planet=Jupiter\n\tgravity: 2.528\n\n@planet=Mars\n\tgravity: 0.38\n"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitForSnapshot(t, w, snapOf(
+		newRec("planet", "Jupiter", map[string]string{"gravity": "2.528"}),
+		newRec("planet", "Mars", map[string]string{"gravity": "0.38"}),
+	))
+
+	// Modified: an existing record's value changes.
+	if err := os.WriteFile(path, []byte("@planet=Jupiter\n\tgravity: 2.6\n\n@planet=Mars\n\tgravity: 0.38\n"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitForSnapshot(t, w, snapOf(
+		newRec("planet", "Jupiter", map[string]string{"gravity": "2.6"}),
+		newRec("planet", "Mars", map[string]string{"gravity": "0.38"}),
+	))
+
+	// Removed: a record is dropped from the file.
+	if err := os.WriteFile(path, []byte("@planet=Jupiter\n\tgravity: 2.6\n"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitForSnapshot(t, w, snapOf(
+		newRec("planet", "Jupiter", map[string]string{"gravity": "2.6"}),
+	))
+
+	// reload updates the snapshot before it sends the corresponding
+	// events on evC, so the last waitForSnapshot above can return
+	// just before the drain goroutine records the final event;
+	// poll the accumulated events too instead of checking them once.
+	var added, modified, removed bool
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		added, modified, removed = false, false, false
+		for _, ev := range events {
+			switch ev.Kind {
+			case Added:
+				if ev.Record.ID() == "Mars" {
+					added = true
+				}
+			case Modified:
+				if ev.Record.ID() == "Jupiter" {
+					if d, ok := ev.Delta["gravity"]; ok && d.Old == "2.528" && d.New == "2.6" {
+						modified = true
+					}
+				}
+			case Removed:
+				if ev.Prev.ID() == "Mars" {
+					removed = true
+				}
+			}
+		}
+		mu.Unlock()
+
+		if added && modified && removed {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("added=%v modified=%v removed=%v, want all true", added, modified, removed)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDiffSnapshotAdded(t *testing.T) {
+	titan := newRec("moon", "Titan", map[string]string{"parent": "Saturn"})
+	events := diffSnapshot(snapOf(), snapOf(titan))
+	if len(events) != 1 {
+		t.Fatalf("%d events, want 1", len(events))
+	}
+	if events[0].Kind != Added {
+		t.Errorf("kind = %v, want Added", events[0].Kind)
+	}
+	if events[0].Record.ID() != "Titan" {
+		t.Errorf("record = %q, want Titan", events[0].Record.ID())
+	}
+}